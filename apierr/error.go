@@ -0,0 +1,56 @@
+// Package apierr gives controllers a single typed error to return instead
+// of ad-hoc gin.H{"error": ...} shapes, and a middleware that turns it into
+// a consistent JSON envelope.
+package apierr
+
+import "net/http"
+
+// Error is pushed onto the gin context via c.Error(...) and rendered by
+// Middleware. UserMessage is safe to show to clients; Err (if set) is
+// logged but never serialized.
+type Error struct {
+	Code        string
+	HTTPStatus  int
+	UserMessage string
+	Err         error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.UserMessage + ": " + e.Err.Error()
+	}
+	return e.UserMessage
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New builds an Error with an explicit code/status/message.
+func New(code string, httpStatus int, userMessage string, err error) *Error {
+	return &Error{Code: code, HTTPStatus: httpStatus, UserMessage: userMessage, Err: err}
+}
+
+// InvalidCredentials covers both "wrong password" and "no such user" so
+// login never reveals which one it was.
+func InvalidCredentials() *Error {
+	return New("invalid_credentials", http.StatusUnauthorized, "invalid credentials", nil)
+}
+
+// NotFound reports that resource could not be located.
+func NotFound(resource string) *Error {
+	return New("not_found", http.StatusNotFound, resource+" not found", nil)
+}
+
+// BadRequest reports a client-side input problem.
+func BadRequest(message string) *Error {
+	return New("bad_request", http.StatusBadRequest, message, nil)
+}
+
+// TooManyRequests reports that a rate or attempt limit was hit.
+func TooManyRequests(message string) *Error {
+	return New("too_many_requests", http.StatusTooManyRequests, message, nil)
+}
+
+// Internal wraps an unexpected error without leaking its details to the client.
+func Internal(err error) *Error {
+	return New("internal_error", http.StatusInternalServerError, "something went wrong", err)
+}
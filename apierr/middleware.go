@@ -0,0 +1,71 @@
+package apierr
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware recovers panics and renders any *Error pushed via c.Error(...)
+// as a uniform JSON body: {"error": {"code", "message"}, "request_id"}.
+// It must be registered after RequestID so request_id is already set.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[%s] panic recovered: %v", c.GetString("request_id"), r)
+				respond(c, Internal(fmt.Errorf("panic: %v", r)))
+			}
+		}()
+
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		apiErr, ok := c.Errors.Last().Err.(*Error)
+		if !ok {
+			apiErr = Internal(c.Errors.Last().Err)
+		}
+		if apiErr.Err != nil {
+			log.Printf("[%s] %s", c.GetString("request_id"), apiErr.Err.Error())
+		}
+		respond(c, apiErr)
+	}
+}
+
+func respond(c *gin.Context, e *Error) {
+	c.JSON(e.HTTPStatus, gin.H{
+		"error": gin.H{
+			"code":    e.Code,
+			"message": e.UserMessage,
+		},
+		"request_id": c.GetString("request_id"),
+	})
+	c.Abort()
+}
+
+// RequestID stamps every request/response pair with an X-Request-ID header,
+// reusing one the caller supplied so a request can be traced across services.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if id == "" {
+			id = generateRequestID()
+		}
+		c.Set("request_id", id)
+		c.Writer.Header().Set("X-Request-ID", id)
+		c.Next()
+	}
+}
+
+func generateRequestID() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%x", raw)
+}
@@ -0,0 +1,168 @@
+package authorization
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"auth/apierr"
+	"auth/models"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AccessTokenTTL is how long an access token issued by GenerateJWT stays
+// valid. Session length beyond this is carried by the refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// GenerateJWT issues a short-lived, signed access token for email/userID.
+// The returned jti identifies the token for introspection and revocation.
+func GenerateJWT(email string, userID uint) (tokenString string, jti string, err error) {
+	jti, err = newJTI()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"email": email,
+		"uid":   userID,
+		"jti":   jti,
+		"iat":   now.Unix(),
+		"exp":   now.Add(AccessTokenTTL).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err = token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	return tokenString, jti, err
+}
+
+// GenerateChallengeJWT issues a short-lived token identifying a user who has
+// passed the password check but still owes a second factor.
+func GenerateChallengeJWT(email string) (string, error) {
+	claims := jwt.MapClaims{
+		"email":     email,
+		"challenge": true,
+		"exp":       time.Now().Add(5 * time.Minute).Unix(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+}
+
+// ParseChallengeJWT validates a 2FA challenge token and returns the email it
+// was issued for.
+func ParseChallengeJWT(tokenString string) (string, error) {
+	claims, err := ParseJWT(tokenString)
+	if err != nil {
+		return "", err
+	}
+
+	if isChallenge, _ := claims["challenge"].(bool); !isChallenge {
+		return "", errors.New("not a 2fa challenge token")
+	}
+
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", errors.New("invalid token claims")
+	}
+
+	return email, nil
+}
+
+// ParseJWT validates tokenString and returns its claims.
+func ParseJWT(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	return claims, nil
+}
+
+// RevokeAccessToken blacklists jti until expiresAt, so a still-unexpired
+// access token stops passing introspection/AuthMiddleware after logout.
+func RevokeAccessToken(DB *gorm.DB, jti string, expiresAt time.Time) error {
+	return DB.Create(&models.RevokedAccessToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// IsAccessTokenRevoked reports whether jti was blacklisted via RevokeAccessToken.
+func IsAccessTokenRevoked(DB *gorm.DB, jti string) bool {
+	var revoked models.RevokedAccessToken
+	err := DB.Where("jti = ?", jti).First(&revoked).Error
+	return err == nil
+}
+
+// AuthMiddleware requires a valid, non-revoked "Bearer <token>" Authorization
+// header and stamps the authenticated email onto the context as "email".
+func AuthMiddleware(DB *gorm.DB) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		header := context.GetHeader("Authorization")
+		if header == "" {
+			context.Error(apierr.New("authorization_required", http.StatusUnauthorized, "authorization header required", nil))
+			context.Abort()
+			return
+		}
+
+		tokenString := strings.TrimPrefix(header, "Bearer ")
+		claims, err := ParseJWT(tokenString)
+		if err != nil {
+			context.Error(apierr.New("invalid_token", http.StatusUnauthorized, "invalid or expired token", nil))
+			context.Abort()
+			return
+		}
+
+		email, ok := claims["email"].(string)
+		if !ok {
+			context.Error(apierr.New("invalid_token_claims", http.StatusUnauthorized, "invalid token claims", nil))
+			context.Abort()
+			return
+		}
+
+		if isChallenge, _ := claims["challenge"].(bool); isChallenge {
+			context.Error(apierr.New("challenge_token_not_allowed", http.StatusUnauthorized, "a 2fa challenge token cannot be used as a session token", nil))
+			context.Abort()
+			return
+		}
+
+		jti, _ := claims["jti"].(string)
+		if jti != "" && IsAccessTokenRevoked(DB, jti) {
+			context.Error(apierr.New("token_revoked", http.StatusUnauthorized, "token has been revoked", nil))
+			context.Abort()
+			return
+		}
+
+		context.Set("email", email)
+		context.Set("jti", jti)
+		context.Set("exp", claims["exp"])
+		if uid, ok := claims["uid"].(float64); ok {
+			context.Set("uid", uint(uid))
+		}
+		context.Next()
+	}
+}
+
+// newJTI returns a random 16-byte hex token id.
+func newJTI() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"auth/apierr"
+	"auth/authorization"
+	"auth/models"
+	"auth/oauth"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// oauthCookieTTL bounds how long the state/verifier cookies survive between
+// the redirect to the provider and the callback coming back.
+const oauthCookieTTL = 10 * 60 // seconds, context.SetCookie wants an int
+
+type linkRequest struct {
+	Code     string `json:"code"`
+	Verifier string `json:"verifier"`
+}
+
+// OAuthLogin starts the Authorization Code + PKCE flow for :provider by
+// redirecting the user to the provider's consent screen.
+func OAuthLogin(context *gin.Context, DB *gorm.DB) {
+	provider, ok := oauth.Get(context.Param("provider"))
+	if !ok {
+		context.Error(apierr.BadRequest("unknown oauth provider"))
+		return
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+	verifier, err := oauth.GenerateVerifier()
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.SetCookie("oauth_state", state, oauthCookieTTL, "/", "", false, true)
+	context.SetCookie("oauth_verifier", verifier, oauthCookieTTL, "/", "", false, true)
+
+	context.Redirect(http.StatusTemporaryRedirect, oauth.AuthorizeURL(provider, state, oauth.ChallengeS256(verifier)))
+}
+
+// OAuthCallback completes the flow started by OAuthLogin: it verifies the
+// state cookie, exchanges the code, resolves the caller's identity, and
+// either logs them into their linked account or creates one.
+func OAuthCallback(context *gin.Context, DB *gorm.DB) {
+	provider, ok := oauth.Get(context.Param("provider"))
+	if !ok {
+		context.Error(apierr.BadRequest("unknown oauth provider"))
+		return
+	}
+
+	verifier, err := verifyStateAndConsumeCookies(context)
+	if err != nil {
+		context.Error(apierr.New("invalid_oauth_state", http.StatusUnauthorized, "invalid or expired oauth state", err))
+		return
+	}
+
+	info, err := exchangeAndFetchUserInfo(context, provider, context.Query("code"), verifier)
+	if err != nil {
+		context.Error(apierr.New("oauth_exchange_failed", http.StatusUnauthorized, "could not complete oauth sign-in", err))
+		return
+	}
+
+	user, err := findOrCreateUserForIdentity(DB, provider.Name, info)
+	if errors.Is(err, errAccountExistsUnlinked) {
+		context.Error(apierr.New("account_exists_unlinked", http.StatusConflict, "an account with this email already exists; log in and link "+provider.Name+" from your account settings", nil))
+		return
+	}
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	if user.TOTPEnabled {
+		challengeToken, err := authorization.GenerateChallengeJWT(user.Email)
+		if err != nil {
+			context.Error(apierr.Internal(err))
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"twofa_required": true, "challenge_token": challengeToken})
+		return
+	}
+
+	pair, err := issueTokenPair(context, DB, user)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, pair)
+}
+
+// LinkProvider attaches a provider identity (obtained by the client through
+// the same Authorization Code + PKCE flow as OAuthLogin) to the
+// authenticated user's account.
+func LinkProvider(context *gin.Context, DB *gorm.DB) {
+	provider, ok := oauth.Get(context.Param("provider"))
+	if !ok {
+		context.Error(apierr.BadRequest("unknown oauth provider"))
+		return
+	}
+
+	var request linkRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	info, err := exchangeAndFetchUserInfo(context, provider, request.Code, request.Verifier)
+	if err != nil {
+		context.Error(apierr.New("oauth_exchange_failed", http.StatusUnauthorized, "could not complete oauth sign-in", err))
+		return
+	}
+
+	var existing models.UserIdentity
+	err = DB.Where("provider = ? AND provider_user_id = ?", provider.Name, info.ProviderUserID).First(&existing).Error
+	if err == nil {
+		context.Error(apierr.New("provider_already_linked", http.StatusConflict, "this provider account is already linked to a user", nil))
+		return
+	}
+
+	email := context.GetString("email")
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	identity := models.UserIdentity{Provider: provider.Name, ProviderUserID: info.ProviderUserID, UserID: user.ID, Email: info.Email}
+	if err := DB.Create(&identity).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"message": "provider linked"})
+}
+
+// UnlinkProvider detaches a provider identity from the authenticated user,
+// refusing if it would leave the account with no way to sign in.
+func UnlinkProvider(context *gin.Context, DB *gorm.DB) {
+	providerName := context.Param("provider")
+	email := context.GetString("email")
+
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	var identity models.UserIdentity
+	if err := DB.Where("user_id = ? AND provider = ?", user.ID, providerName).First(&identity).Error; err != nil {
+		context.Error(apierr.NotFound("provider link"))
+		return
+	}
+
+	var otherIdentities int64
+	DB.Model(&models.UserIdentity{}).Where("user_id = ? AND provider <> ?", user.ID, providerName).Count(&otherIdentities)
+
+	if user.Password == "" && otherIdentities == 0 {
+		context.Error(apierr.BadRequest("cannot unlink the only way to sign in to this account"))
+		return
+	}
+
+	if err := DB.Delete(&identity).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"message": "provider unlinked"})
+}
+
+// verifyStateAndConsumeCookies checks the callback's state query parameter
+// against the cookie OAuthLogin set, clears both cookies, and returns the
+// PKCE verifier for the exchange.
+func verifyStateAndConsumeCookies(context *gin.Context) (verifier string, err error) {
+	cookieState, err := context.Cookie("oauth_state")
+	if err != nil || cookieState == "" || cookieState != context.Query("state") {
+		return "", errInvalidOAuthState
+	}
+
+	verifier, err = context.Cookie("oauth_verifier")
+	if err != nil || verifier == "" {
+		return "", errInvalidOAuthState
+	}
+
+	context.SetCookie("oauth_state", "", -1, "/", "", false, true)
+	context.SetCookie("oauth_verifier", "", -1, "/", "", false, true)
+
+	return verifier, nil
+}
+
+func exchangeAndFetchUserInfo(context *gin.Context, provider oauth.Provider, code, verifier string) (*oauth.UserInfo, error) {
+	token, err := oauth.ExchangeCode(context, provider, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+	return oauth.FetchUserInfo(context, provider, token)
+}
+
+// findOrCreateUserForIdentity logs an OAuth user in via an existing linked
+// identity, or creates both the identity and its backing user on first
+// login. If the provider's email already belongs to an account with no
+// matching identity, it refuses to create a second account under that email
+// and reports errAccountExistsUnlinked instead, so the caller can point the
+// user at the explicit /api/user/link/:provider flow.
+func findOrCreateUserForIdentity(DB *gorm.DB, providerName string, info *oauth.UserInfo) (*models.User, error) {
+	var identity models.UserIdentity
+	err := DB.Where("provider = ? AND provider_user_id = ?", providerName, info.ProviderUserID).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := DB.First(&user, identity.UserID).Error; err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+
+	var existing models.User
+	if err := DB.Where("email = ?", info.Email).First(&existing).Error; err == nil {
+		return nil, errAccountExistsUnlinked
+	}
+
+	user := models.User{Email: info.Email, IsVerified: true}
+	if err := DB.Create(&user).Error; err != nil {
+		return nil, err
+	}
+
+	identity = models.UserIdentity{Provider: providerName, ProviderUserID: info.ProviderUserID, UserID: user.ID, Email: info.Email}
+	if err := DB.Create(&identity).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+var errInvalidOAuthState = errors.New("invalid or expired oauth state")
+var errAccountExistsUnlinked = errors.New("an account with this email already exists and is not linked to this provider")
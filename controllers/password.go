@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	stdcontext "context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"auth/apierr"
+	"auth/models"
+	"auth/otp"
+	"auth/ratelimit"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// passwordResetTTL is how long a forgot-password token stays redeemable.
+const passwordResetTTL = 15 * time.Minute
+
+type forgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// ForgotPassword always responds 200, whether or not the email is
+// registered, so the endpoint can't be used to enumerate accounts. When the
+// user does exist, it issues a single-use reset token and emails a link.
+func ForgotPassword(context *gin.Context, DB *gorm.DB, sender otp.Sender, emailLimiter *ratelimit.Limiter) {
+	var request forgotPasswordRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	if !emailLimiter.Allow(request.Email) {
+		context.Error(apierr.TooManyRequests("too many requests, please try again later"))
+		return
+	}
+
+	var user models.User
+	if err := DB.Where("email = ?", request.Email).First(&user).Error; err == nil {
+		if err := sendResetEmail(DB, sender, &user); err != nil {
+			log.Println("Error in issuing password reset:", err)
+		}
+	}
+
+	context.JSON(http.StatusOK, gin.H{"message": "if that email is registered, a reset link has been sent"})
+}
+
+// ResetPassword redeems a forgot-password token: it looks the token up by
+// its hash, checks it's neither expired nor already used, sets the new
+// password, marks the token used, and revokes every outstanding refresh
+// token so a stolen session can't outlive the password change.
+func ResetPassword(context *gin.Context, DB *gorm.DB) {
+	var request resetPasswordRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	hash := hashResetToken(request.Token)
+
+	var reset models.PasswordReset
+	if err := DB.Where("token_hash = ?", hash).First(&reset).Error; err != nil {
+		context.Error(apierr.BadRequest("invalid or expired token"))
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(reset.TokenHash), []byte(hash)) != 1 {
+		context.Error(apierr.BadRequest("invalid or expired token"))
+		return
+	}
+
+	if reset.UsedAt != nil || reset.ExpiresAt.Before(time.Now()) {
+		context.Error(apierr.BadRequest("invalid or expired token"))
+		return
+	}
+
+	var user models.User
+	if err := DB.First(&user, reset.UserID).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	if err := user.HashPassword(request.NewPassword); err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+	if err := DB.Save(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	now := time.Now()
+	reset.UsedAt = &now
+	if err := DB.Save(&reset).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	revokeAllRefreshTokens(DB, user.ID)
+
+	context.JSON(http.StatusOK, gin.H{"message": "password has been reset"})
+}
+
+// sendResetEmail generates the token, stores its hash, and emails the link
+// through the same Sender RegisterUser uses for OTPs.
+func sendResetEmail(DB *gorm.DB, sender otp.Sender, user *models.User) error {
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+
+	reset := models.PasswordReset{
+		TokenHash: hashResetToken(token),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := DB.Create(&reset).Error; err != nil {
+		return err
+	}
+
+	link := os.Getenv("PASSWORD_RESET_URL_BASE") + "/reset?token=" + token
+
+	go sender.Send(stdcontext.Background(), user.Email, "Reset your password",
+		"Use the link below to reset your password. It expires in 15 minutes and can only be used once.\n\n"+link)
+	return nil
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newResetToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
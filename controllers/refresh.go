@@ -0,0 +1,218 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"auth/apierr"
+	"auth/authorization"
+	"auth/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// refreshTokenTTL is how long a refresh token stays valid.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type introspectRequest struct {
+	Token string `json:"token"`
+}
+
+// tokenPair is what every login path returns: a short-lived access token
+// plus the refresh token that can mint the next one.
+type tokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// issueTokenPair generates a fresh access+refresh pair for user and persists
+// the refresh token row.
+func issueTokenPair(context *gin.Context, DB *gorm.DB, user *models.User) (tokenPair, error) {
+	accessToken, _, err := authorization.GenerateJWT(user.Email, user.ID)
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return tokenPair{}, err
+	}
+
+	now := time.Now()
+	record := models.RefreshToken{
+		ID:                refreshToken,
+		UserID:            user.ID,
+		IssuedAt:          now,
+		ExpiresAt:         now.Add(refreshTokenTTL),
+		ClientFingerprint: fingerprint(context),
+	}
+	if err := DB.Create(&record).Error; err != nil {
+		return tokenPair{}, err
+	}
+
+	return tokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshController validates a refresh token and rotates it: the presented
+// token is revoked and a new access+refresh pair is issued in its place.
+// Presenting a token that was already rotated away is treated as reuse and
+// revokes every active refresh token for that user.
+func RefreshController(context *gin.Context, DB *gorm.DB) {
+	var request refreshRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	var stored models.RefreshToken
+	if err := DB.Where("id = ?", request.RefreshToken).First(&stored).Error; err != nil {
+		context.Error(apierr.New("invalid_refresh_token", http.StatusUnauthorized, "invalid refresh token", nil))
+		return
+	}
+
+	if stored.RevokedAt != nil {
+		revokeAllRefreshTokens(DB, stored.UserID)
+		context.Error(apierr.New("refresh_token_reuse", http.StatusUnauthorized, "refresh token reuse detected, all sessions revoked", nil))
+		return
+	}
+
+	if stored.ExpiresAt.Before(time.Now()) {
+		context.Error(apierr.New("refresh_token_expired", http.StatusUnauthorized, "refresh token expired", nil))
+		return
+	}
+
+	var user models.User
+	if err := DB.First(&user, stored.UserID).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	pair, err := issueTokenPair(context, DB, &user)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	now := time.Now()
+	stored.RevokedAt = &now
+	stored.ReplacedBy = pair.RefreshToken
+	if err := DB.Save(&stored).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, pair)
+}
+
+// Logout revokes the presented refresh token and blacklists the access
+// token that authenticated this request.
+func Logout(context *gin.Context, DB *gorm.DB) {
+	var request refreshRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	now := time.Now()
+	if err := DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", request.RefreshToken).
+		Update("revoked_at", now).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	blacklistCurrentAccessToken(context, DB)
+	context.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// LogoutAll revokes every active refresh token for the authenticated user,
+// ending every session on every device.
+func LogoutAll(context *gin.Context, DB *gorm.DB) {
+	uid, ok := context.Get("uid")
+	if !ok {
+		context.Error(apierr.New("invalid_token_claims", http.StatusUnauthorized, "invalid token claims", nil))
+		return
+	}
+
+	revokeAllRefreshTokens(DB, uid.(uint))
+	blacklistCurrentAccessToken(context, DB)
+	context.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions"})
+}
+
+// Introspect implements an RFC 7662-style check of an access token's
+// validity, for resource servers that only hold the token, not the secret.
+func Introspect(context *gin.Context, DB *gorm.DB) {
+	var request introspectRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	claims, err := authorization.ParseJWT(request.Token)
+	if err != nil {
+		context.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	if jti, _ := claims["jti"].(string); jti != "" && authorization.IsAccessTokenRevoked(DB, jti) {
+		context.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{
+		"active": true,
+		"sub":    claims["email"],
+		"exp":    claims["exp"],
+		"iat":    claims["iat"],
+	})
+}
+
+// revokeAllRefreshTokens revokes every still-active refresh token for userID.
+func revokeAllRefreshTokens(DB *gorm.DB, userID uint) {
+	now := time.Now()
+	DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now)
+}
+
+// blacklistCurrentAccessToken revokes the access token that authenticated
+// the current request, using the jti/exp AuthMiddleware stashed on context.
+func blacklistCurrentAccessToken(context *gin.Context, DB *gorm.DB) {
+	jti, _ := context.Get("jti")
+	expClaim, ok := context.Get("exp")
+	if jti == nil || jti == "" || !ok {
+		return
+	}
+
+	expUnix, ok := expClaim.(float64)
+	if !ok {
+		return
+	}
+
+	authorization.RevokeAccessToken(DB, jti.(string), time.Unix(int64(expUnix), 0))
+}
+
+// fingerprint summarizes the requesting client (user-agent + IP) so a stolen
+// refresh token is at least auditable against the session it was issued to.
+func fingerprint(context *gin.Context) string {
+	sum := sha256.Sum256([]byte(context.Request.UserAgent() + "|" + context.ClientIP()))
+	return hex.EncodeToString(sum[:])
+}
+
+// newOpaqueToken returns a random 32-byte, base64url-encoded token.
+func newOpaqueToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
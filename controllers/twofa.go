@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"auth/apierr"
+	"auth/authorization"
+	"auth/models"
+	"auth/totp"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const issuer = "Authentication-go"
+
+type verifyTOTPRequest struct {
+	Code string `json:"code"`
+}
+
+type twofaLoginRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+}
+
+// EnableTOTP starts TOTP enrollment for the authenticated user: it generates
+// a new secret, stores it encrypted, and returns the otpauth:// URI and a
+// QR code the user scans with their authenticator app. TOTPEnabled stays
+// false until the first code is confirmed via VerifyTOTP.
+func EnableTOTP(context *gin.Context, DB *gorm.DB) {
+	email := context.GetString("email")
+
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	encrypted, err := totp.Encrypt(secret)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	user.TOTPSecret = encrypted
+	user.TOTPEnabled = false
+	user.TOTPLastCounter = 0
+	if err := DB.Save(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	uri := totp.URI(issuer, user.Email, secret)
+	qr, err := totp.QRCodeDataURI(uri)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"otpauth_url": uri, "qr_code": qr})
+}
+
+// VerifyTOTP confirms a 6-digit code against the pending or already-enabled
+// secret. The first successful call flips TOTPEnabled to true and issues the
+// user's recovery codes (only returned this once, in plaintext).
+func VerifyTOTP(context *gin.Context, DB *gorm.DB) {
+	email := context.GetString("email")
+
+	var request verifyTOTPRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	if user.TOTPSecret == "" {
+		context.Error(apierr.BadRequest("TOTP enrollment has not been started"))
+		return
+	}
+
+	secret, err := totp.Decrypt(user.TOTPSecret)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	counter, ok, err := totp.Validate(secret, request.Code, time.Now().Unix(), user.TOTPLastCounter)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+	if !ok {
+		context.Error(apierr.New("invalid_totp_code", http.StatusUnauthorized, "invalid or expired code", nil))
+		return
+	}
+
+	user.TOTPLastCounter = counter
+	firstEnrollment := !user.TOTPEnabled
+	response := gin.H{"message": "TOTP verified"}
+
+	if firstEnrollment {
+		user.TOTPEnabled = true
+
+		plainCodes, hashedCodes, err := totp.GenerateRecoveryCodes(10)
+		if err != nil {
+			context.Error(apierr.Internal(err))
+			return
+		}
+
+		codes := make(models.RecoveryCodes, len(hashedCodes))
+		for i, hash := range hashedCodes {
+			codes[i] = models.RecoveryCode{Hash: hash}
+		}
+		user.RecoveryCodes = codes
+
+		response["recovery_codes"] = plainCodes
+	}
+
+	if err := DB.Save(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, response)
+}
+
+// DisableTOTP turns off TOTP for the authenticated user and clears the
+// stored secret and recovery codes.
+func DisableTOTP(context *gin.Context, DB *gorm.DB) {
+	email := context.GetString("email")
+
+	err := DB.Model(&models.User{}).Where("email = ?", email).Updates(map[string]interface{}{
+		"totp_enabled":      false,
+		"totp_secret":       "",
+		"totp_last_counter": 0,
+		"recovery_codes":    nil,
+	}).Error
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, gin.H{"message": "TOTP disabled"})
+}
+
+// LoginWithTOTP exchanges a 2FA challenge token plus a TOTP or recovery code
+// for a full session token, completing the login started by LoginController.
+func LoginWithTOTP(context *gin.Context, DB *gorm.DB) {
+	var request twofaLoginRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
+
+	email, err := authorization.ParseChallengeJWT(request.ChallengeToken)
+	if err != nil {
+		context.Error(apierr.New("invalid_challenge_token", http.StatusUnauthorized, "invalid or expired challenge token", nil))
+		return
+	}
+
+	var user models.User
+	if err := DB.Where("email = ?", email).First(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	if !user.TOTPEnabled {
+		context.Error(apierr.BadRequest("TOTP is not enabled for this account"))
+		return
+	}
+
+	if verified := tryTOTPCode(&user, request.Code); verified {
+		if err := DB.Model(&user).Update("totp_last_counter", user.TOTPLastCounter).Error; err != nil {
+			context.Error(apierr.Internal(err))
+			return
+		}
+	} else if !tryRecoveryCode(DB, &user, request.Code) {
+		context.Error(apierr.New("invalid_totp_code", http.StatusUnauthorized, "invalid code", nil))
+		return
+	}
+
+	pair, err := issueTokenPair(context, DB, &user)
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	context.JSON(http.StatusOK, pair)
+}
+
+// tryTOTPCode validates code as a TOTP code and, on success, records the
+// matched counter on user; the caller saves it so the code cannot be replayed.
+func tryTOTPCode(user *models.User, code string) bool {
+	secret, err := totp.Decrypt(user.TOTPSecret)
+	if err != nil {
+		return false
+	}
+
+	counter, ok, err := totp.Validate(secret, code, time.Now().Unix(), user.TOTPLastCounter)
+	if err != nil || !ok {
+		return false
+	}
+
+	user.TOTPLastCounter = counter
+	return true
+}
+
+// tryRecoveryCode validates code as one of the user's unused recovery codes
+// and, on success, marks it consumed.
+func tryRecoveryCode(DB *gorm.DB, user *models.User, code string) bool {
+	for i, recoveryCode := range user.RecoveryCodes {
+		if recoveryCode.Used {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(recoveryCode.Hash), []byte(code)) == nil {
+			user.RecoveryCodes[i].Used = true
+			return DB.Model(user).Update("recovery_codes", user.RecoveryCodes).Error == nil
+		}
+	}
+	return false
+}
@@ -1,22 +1,20 @@
 package controllers
 
 import (
-	"auth/authorization"
-	"auth/models"
-	"crypto/rand"
-	"fmt"
+	stdcontext "context"
+	"errors"
 	"log"
-	"math/big"
 	"net/http"
-	"net/smtp"
-	"os"
+
+	"auth/apierr"
+	"auth/authorization"
+	"auth/models"
+	"auth/otp"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-var cache = make(map[string]string)
-
 type TokenRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -29,116 +27,113 @@ type OTPRequest struct {
 
 // RegisterUser registers a user in the system.
 //
-// It takes two parameters:
+// It takes five parameters:
 // - context: a pointer to the gin.Context object.
 // - DB: a pointer to the gorm.DB object.
+// - store: where the generated OTP is kept until it's verified.
+// - sender: how the OTP is delivered to the user (email, SMS, ...).
+// - cfg: the expiry applied to the stored OTP.
 //
 // It does the following:
 // 1. Binds the JSON request body to the user object.
 // 2. Hashes the user's password.
 // 3. Creates a record in the database.
-// 4. Sends an OTP (One-Time Password) to the user's email address.
+// 4. Generates an OTP, stores it with a TTL, and sends it to the user.
 // 5. Returns the user ID and email in the response.
-func RegisterUser(context *gin.Context, DB *gorm.DB) {
+func RegisterUser(context *gin.Context, DB *gorm.DB, store otp.Store, sender otp.Sender, cfg otp.Config) {
 	var user models.User
 	if err := context.ShouldBindJSON(&user); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		context.Abort()
+		context.Error(apierr.BadRequest(err.Error()))
 		return
 	}
 	if err := user.HashPassword(user.Password); err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		context.Abort()
+		context.Error(apierr.Internal(err))
+		return
+	}
+	if err := DB.Create(&user).Error; err != nil {
+		context.Error(apierr.Internal(err))
 		return
 	}
-	record := DB.Create(&user)
-	//! Sending OTP logic //
-
-	/*
-		1. Generate a random 6-digit number
-		2. Send the OTP to the user's email
-		3. Store the OTP in the map
-
-	*/
-
-	auth := smtp.PlainAuth("", os.Getenv("EMAIL"), os.Getenv("PASSWORD"), "smtp.gmail.com")
-
-	to := []string{user.Email}
-	otp, err := generateOTP()
-	cache[user.Email] = otp
 
+	code, err := otp.GenerateCode()
 	if err != nil {
-		log.Fatal("error in generating OTP", err)
+		context.Error(apierr.Internal(err))
+		return
+	}
+
+	if err := store.Put(context, user.Email, code, cfg.Expiry); err != nil {
+		context.Error(apierr.Internal(err))
+		return
 	}
-	message := []byte("To : " + user.Email + "Subject : OTP for Registration \r\n  \r\n" +
-		"Your OTP For registration is " + otp + "\n")
 
 	go func() {
-		err := smtp.SendMail("smtp.gmail.com:587", auth, os.Getenv("EMAIL"), to, message)
-		if err != nil {
+		if err := sender.Send(stdcontext.Background(), user.Email, "OTP for Registration", "Your OTP For registration is "+code); err != nil {
 			log.Println("Error in sending OTP:", err)
 		}
 	}()
 
-	////-------///////
-
-	if record.Error != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": record.Error.Error()})
-		context.Abort()
-		return
-	}
 	context.JSON(http.StatusCreated, gin.H{"userId": user.ID, "email": user.Email})
 }
 
 // CheckOtp checks the OTP provided by the user.
 //
-// It takes in two parameters:
+// It takes in four parameters:
 // - context: a pointer to a gin.Context object representing the HTTP request context.
 // - DB: a pointer to a gorm.DB object representing the database connection.
+// - store: where RegisterUser's OTP was kept.
+// - cfg: the expiry/attempt limits that were used to generate the OTP.
 //
 // This function first parses the OTP request from the JSON payload of the HTTP request.
 // If the JSON parsing fails, it returns a JSON response with the corresponding error and aborts the request.
 //
-// Then it checks if the OTP exists in the cache. If it doesn't exist, it returns a JSON response with an "invalid credentials" error and aborts the request.
+// Then it looks up the OTP in the store. If it doesn't exist (or has expired), it returns a JSON
+// response with an "invalid credentials" error and aborts the request.
 //
-// Next, it compares the received OTP with the OTP in the cache. If they don't match, it returns a JSON response with a "Please Enter Valid OTP" error.
+// Next, it compares the received OTP with the stored OTP, counting the attempt regardless of
+// outcome and locking the key out once cfg.MaxAttempts is reached.
 //
-// After that, it updates the IsVerified field of the user with the matching email in the database.
-// If the update fails, it returns a JSON response with the corresponding error.
+// After a match, it deletes the OTP from the store (so it can't be replayed) and updates the
+// IsVerified field of the user with the matching email in the database.
 //
 // Finally, it returns a JSON response with a "OTP Verified" message indicating successful verification.
-func CheckOtp(context *gin.Context, DB *gorm.DB) {
+func CheckOtp(context *gin.Context, DB *gorm.DB, store otp.Store, cfg otp.Config) {
+	var request OTPRequest
+	if err := context.ShouldBindJSON(&request); err != nil {
+		context.Error(apierr.BadRequest(err.Error()))
+		return
+	}
 
-	var otp OTPRequest
-	if err := context.ShouldBindJSON(&otp); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		context.Abort()
+	code, err := store.Get(context, request.Email)
+	if err != nil {
+		context.Error(apierr.InvalidCredentials())
 		return
 	}
 
-	value, exists := cache[otp.Email]
-	if !exists {
-		context.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-		context.Abort()
+	attempts, err := store.IncrAttempts(context, request.Email)
+	if err != nil {
+		context.Error(apierr.InvalidCredentials())
+		return
+	}
+	if attempts > cfg.MaxAttempts {
+		store.Delete(context, request.Email)
+		context.Error(apierr.TooManyRequests("too many attempts, request a new OTP"))
 		return
 	}
 
-	if value != otp.OTP {
-		context.JSON(http.StatusBadRequest, gin.H{"error": "Please Enter Valid OTP"})
+	if code != request.OTP {
+		context.Error(apierr.BadRequest("Please Enter Valid OTP"))
+		return
 	}
 
-	go func() {
-		// var user models.User
-		err := DB.Model(&models.User{}).Where("email = ?", otp.Email).Updates(models.User{IsVerified: true}).Error
-		if err != nil {
-			context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
-		}
+	store.Delete(context, request.Email)
 
-	}()
+	err = DB.Model(&models.User{}).Where("email = ?", request.Email).Updates(models.User{IsVerified: true}).Error
+	if err != nil {
+		context.Error(apierr.Internal(err))
+		return
+	}
 
 	context.JSON(http.StatusOK, gin.H{"message": "OTP Verified"})
-
 }
 
 // LoginController handles the login request and generates a JWT token if the credentials are valid.
@@ -158,49 +153,40 @@ func LoginController(context *gin.Context, DB *gorm.DB) {
 	var request TokenRequest
 	var user models.User
 	if err := context.ShouldBindJSON(&request); err != nil {
-		context.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		context.Abort()
+		context.Error(apierr.BadRequest(err.Error()))
 		return
 	}
-	// check if email exists and password is correct
+	// check if email exists and password is correct. A missing record is
+	// reported the same way as a wrong password so login can't be used to
+	// enumerate registered emails.
 	record := DB.Where("email = ?", request.Email).First(&user)
 	if record.Error != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": record.Error.Error()})
-		context.Abort()
+		if errors.Is(record.Error, gorm.ErrRecordNotFound) {
+			context.Error(apierr.InvalidCredentials())
+			return
+		}
+		context.Error(apierr.Internal(record.Error))
 		return
 	}
-	credentialError := user.CheckPassword(request.Password)
-	if credentialError != nil {
-		context.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
-		context.Abort()
+	if err := user.CheckPassword(request.Password); err != nil {
+		context.Error(apierr.InvalidCredentials())
 		return
 	}
-	tokenString, err := authorization.GenerateJWT(user.Email)
-	if err != nil {
-		context.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		context.Abort()
+
+	if user.TOTPEnabled {
+		challengeToken, err := authorization.GenerateChallengeJWT(user.Email)
+		if err != nil {
+			context.Error(apierr.Internal(err))
+			return
+		}
+		context.JSON(http.StatusOK, gin.H{"twofa_required": true, "challenge_token": challengeToken})
 		return
 	}
-	context.JSON(http.StatusOK, gin.H{"token": tokenString})
-}
 
-func generateOTP() (string, error) {
-	// Define the range for the OTP (5 digits)
-	min := int64(10000)
-	max := int64(99999)
-
-	// Generate a cryptographically secure random number within the defined range
-
-	randomInt, err := rand.Int(rand.Reader, new(big.Int).Sub(big.NewInt(max), big.NewInt(min)))
+	pair, err := issueTokenPair(context, DB, &user)
 	if err != nil {
-		return "", err
+		context.Error(apierr.Internal(err))
+		return
 	}
-
-	// Add the minimum value to ensure a 5-digit OTP
-	otpValue := randomInt.Int64() + min
-
-	// Format the OTP as a string with leading zeros
-	otp := fmt.Sprintf("%05d", otpValue)
-
-	return (otp), nil
+	context.JSON(http.StatusOK, pair)
 }
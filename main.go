@@ -3,18 +3,30 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
+	"auth/apierr"
+	"auth/authorization"
 	"auth/controllers"
 	"auth/database"
+	"auth/oauth"
+	"auth/otp"
+	"auth/ratelimit"
 
 	"auth/models"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
+var otpStore otp.Store
+var otpSender otp.Sender
+var otpConfig otp.Config
+var passwordResetIPLimiter = ratelimit.New(3, time.Hour)
+var passwordResetEmailLimiter = ratelimit.New(3, time.Hour)
 
 func main() {
 	var dbErr error
@@ -24,6 +36,8 @@ func main() {
 		log.Fatal(err)
 	}
 
+	oauth.LoadProviders()
+
 	config := &database.Config{
 		Host:     os.Getenv("DB_HOST"),
 		Port:     os.Getenv("DB_PORT"),
@@ -44,6 +58,25 @@ func main() {
 		log.Fatal("could not migrate")
 	}
 
+	err = models.MigrateTokens(DB)
+	if err != nil {
+		log.Fatal("could not migrate")
+	}
+
+	err = models.MigrateIdentities(DB)
+	if err != nil {
+		log.Fatal("could not migrate")
+	}
+
+	err = models.MigratePasswordResets(DB)
+	if err != nil {
+		log.Fatal("could not migrate")
+	}
+
+	otpStore = newOTPStore()
+	otpSender = newOTPSender()
+	otpConfig = otp.ConfigFromEnv()
+
 	router := initRouter()
 
 	router.Run(":8080")
@@ -52,18 +85,103 @@ func main() {
 
 func initRouter() *gin.Engine {
 	router := gin.Default()
+	router.Use(apierr.RequestID(), apierr.Middleware())
+
+	oauthGroup := router.Group("/api/oauth")
+	{
+		oauthGroup.GET("/:provider/login", func(req *gin.Context) {
+			controllers.OAuthLogin(req, DB)
+		})
+		oauthGroup.GET("/:provider/callback", func(req *gin.Context) {
+			controllers.OAuthCallback(req, DB)
+		})
+	}
+
 	api := router.Group("/api")
 	{
 		api.POST("/user/login", func(req *gin.Context) {
 			controllers.LoginController(req, DB)
 		})
 		api.POST("/user/register", func(req *gin.Context) {
-			controllers.RegisterUser(req, DB)
+			controllers.RegisterUser(req, DB, otpStore, otpSender, otpConfig)
 		})
 		api.POST("/user/otp", func(req *gin.Context) {
-			controllers.CheckOtp(req, DB)
+			controllers.CheckOtp(req, DB, otpStore, otpConfig)
+		})
+		api.POST("/user/2fa/login", func(req *gin.Context) {
+			controllers.LoginWithTOTP(req, DB)
+		})
+		api.POST("/user/refresh", func(req *gin.Context) {
+			controllers.RefreshController(req, DB)
+		})
+		api.POST("/user/introspect", func(req *gin.Context) {
+			controllers.Introspect(req, DB)
+		})
+		api.POST("/user/forgot-password", ratelimit.Middleware(passwordResetIPLimiter, func(c *gin.Context) string {
+			return c.ClientIP()
+		}), func(req *gin.Context) {
+			controllers.ForgotPassword(req, DB, otpSender, passwordResetEmailLimiter)
+		})
+		api.POST("/user/reset-password", func(req *gin.Context) {
+			controllers.ResetPassword(req, DB)
 		})
 
+		twofa := api.Group("/user/2fa")
+		twofa.Use(authorization.AuthMiddleware(DB))
+		{
+			twofa.POST("/enable", func(req *gin.Context) {
+				controllers.EnableTOTP(req, DB)
+			})
+			twofa.POST("/verify", func(req *gin.Context) {
+				controllers.VerifyTOTP(req, DB)
+			})
+			twofa.POST("/disable", func(req *gin.Context) {
+				controllers.DisableTOTP(req, DB)
+			})
+		}
+
+		authed := api.Group("/user")
+		authed.Use(authorization.AuthMiddleware(DB))
+		{
+			authed.POST("/logout", func(req *gin.Context) {
+				controllers.Logout(req, DB)
+			})
+			authed.POST("/logout-all", func(req *gin.Context) {
+				controllers.LogoutAll(req, DB)
+			})
+			authed.POST("/link/:provider", func(req *gin.Context) {
+				controllers.LinkProvider(req, DB)
+			})
+			authed.DELETE("/link/:provider", func(req *gin.Context) {
+				controllers.UnlinkProvider(req, DB)
+			})
+		}
 	}
 	return router
 }
+
+// newOTPStore selects the OTP Store backend from OTP_STORE_BACKEND ("memory"
+// or "redis"), defaulting to an in-memory store swept every minute.
+func newOTPStore() otp.Store {
+	switch os.Getenv("OTP_STORE_BACKEND") {
+	case "redis":
+		client := redis.NewClient(&redis.Options{Addr: os.Getenv("REDIS_ADDR")})
+		return otp.NewRedisStore(client)
+	default:
+		return otp.NewMemoryStore(time.Minute)
+	}
+}
+
+// newOTPSender selects the OTP Sender backend from OTP_SENDER_BACKEND
+// ("smtp", "twilio", or "noop"), defaulting to the SMTP sender RegisterUser
+// always used.
+func newOTPSender() otp.Sender {
+	switch os.Getenv("OTP_SENDER_BACKEND") {
+	case "twilio":
+		return otp.NewTwilioSender(os.Getenv("TWILIO_ACCOUNT_SID"), os.Getenv("TWILIO_AUTH_TOKEN"), os.Getenv("TWILIO_FROM_NUMBER"))
+	case "noop":
+		return otp.NoopSender{}
+	default:
+		return otp.NewSMTPSender("smtp.gmail.com", "smtp.gmail.com:587", os.Getenv("EMAIL"), os.Getenv("PASSWORD"))
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordReset is a single-use, time-limited token for the forgot-password
+// flow. The token itself is never stored, only its SHA-256 hash.
+type PasswordReset struct {
+	TokenHash string `gorm:"primaryKey"`
+	UserID    uint
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// MigratePasswordResets runs the auto-migration for the PasswordReset model.
+func MigratePasswordResets(db *gorm.DB) error {
+	return db.AutoMigrate(&PasswordReset{})
+}
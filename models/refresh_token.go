@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one opaque refresh token issued to a user. The token's ID
+// is the value the client presents; there is no separate lookup key.
+type RefreshToken struct {
+	ID                string `gorm:"primaryKey"`
+	UserID            uint
+	IssuedAt          time.Time
+	ExpiresAt         time.Time
+	RevokedAt         *time.Time
+	ReplacedBy        string
+	ClientFingerprint string
+}
+
+// RevokedAccessToken records an access token's JTI that was invalidated
+// before its natural expiry (e.g. by logout), so introspection can still
+// reject it.
+type RevokedAccessToken struct {
+	JTI       string `gorm:"primaryKey"`
+	ExpiresAt time.Time
+}
+
+// MigrateTokens runs the auto-migration for the refresh token and revoked
+// access token tables.
+func MigrateTokens(db *gorm.DB) error {
+	return db.AutoMigrate(&RefreshToken{}, &RevokedAccessToken{})
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// User represents a registered account.
+type User struct {
+	gorm.Model
+	Email      string `json:"email" gorm:"unique"`
+	Password   string `json:"password"`
+	IsVerified bool   `json:"isVerified"`
+
+	// TOTP-based two-factor authentication.
+	TOTPSecret      string        `json:"-" gorm:"column:totp_secret"`           // encrypted at rest
+	TOTPEnabled     bool          `json:"totpEnabled" gorm:"column:totp_enabled"`
+	TOTPLastCounter int64         `json:"-" gorm:"column:totp_last_counter"` // last accepted 30s step, rejects replay
+	RecoveryCodes   RecoveryCodes `json:"-" gorm:"column:recovery_codes;type:jsonb"`
+}
+
+// RecoveryCode is a single bcrypt-hashed TOTP recovery code.
+type RecoveryCode struct {
+	Hash string `json:"hash"`
+	Used bool   `json:"used"`
+}
+
+// RecoveryCodes is stored as a JSON array in the recovery_codes column.
+type RecoveryCodes []RecoveryCode
+
+// Value implements driver.Valuer so gorm can marshal RecoveryCodes to jsonb.
+func (r RecoveryCodes) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan implements sql.Scanner so gorm can unmarshal RecoveryCodes from jsonb.
+func (r *RecoveryCodes) Scan(value interface{}) error {
+	if value == nil {
+		*r = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("RecoveryCodes: unsupported scan type")
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// MigrateUsers runs the auto-migration for the User model.
+func MigrateUsers(db *gorm.DB) error {
+	return db.AutoMigrate(&User{})
+}
+
+// HashPassword replaces password with its bcrypt hash and stores it on the user.
+func (user *User) HashPassword(password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.Password = string(hashedPassword)
+	return nil
+}
+
+// CheckPassword compares password against the user's stored hash.
+func (user *User) CheckPassword(password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+}
@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+// UserIdentity links one local User to an account on an external OAuth2/OIDC
+// provider, so a single user can sign in with more than one provider.
+type UserIdentity struct {
+	gorm.Model
+	Provider       string `gorm:"uniqueIndex:idx_provider_user_id"`
+	ProviderUserID string `gorm:"uniqueIndex:idx_provider_user_id"`
+	UserID         uint
+	Email          string
+}
+
+// MigrateIdentities runs the auto-migration for the UserIdentity model.
+func MigrateIdentities(db *gorm.DB) error {
+	return db.AutoMigrate(&UserIdentity{})
+}
@@ -0,0 +1,21 @@
+package oauth
+
+import (
+	"net/url"
+	"strings"
+)
+
+// AuthorizeURL builds the URL the user is redirected to in order to start
+// the Authorization Code + PKCE flow.
+func AuthorizeURL(p Provider, state, codeChallenge string) string {
+	query := url.Values{}
+	query.Set("client_id", p.ClientID)
+	query.Set("redirect_uri", p.RedirectURL)
+	query.Set("response_type", "code")
+	query.Set("scope", strings.Join(p.Scopes, " "))
+	query.Set("state", state)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	return p.AuthURL + "?" + query.Encode()
+}
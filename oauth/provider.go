@@ -0,0 +1,62 @@
+// Package oauth implements the OAuth2 Authorization Code flow with PKCE
+// against the providers registered in Providers.
+package oauth
+
+import "os"
+
+// Provider holds everything needed to drive the Authorization Code flow
+// against one OAuth2/OIDC provider.
+type Provider struct {
+	Name         string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string // set for providers that return a verifiable id_token
+	Issuer       string // expected id_token "iss", checked alongside JWKSURL
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Providers is the registry consulted by the /api/oauth/:provider/* routes.
+// It's empty until LoadProviders populates it; main() calls that after
+// godotenv.Load so the os.Getenv calls below see the .env values instead of
+// whatever was (or wasn't) already in the process environment at init time.
+var Providers = map[string]Provider{}
+
+// LoadProviders (re)builds Providers from the current environment. Call it
+// once the process environment is final (after godotenv.Load), the same way
+// main() defers building otpStore/otpSender until then.
+func LoadProviders() {
+	Providers = map[string]Provider{
+		"google": {
+			Name:         "google",
+			AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+			TokenURL:     "https://oauth2.googleapis.com/token",
+			UserInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+			JWKSURL:      "https://www.googleapis.com/oauth2/v3/certs",
+			Issuer:       "https://accounts.google.com",
+			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OAUTH_REDIRECT_BASE_URL") + "/api/oauth/google/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+		},
+		"github": {
+			Name:         "github",
+			AuthURL:      "https://github.com/login/oauth/authorize",
+			TokenURL:     "https://github.com/login/oauth/access_token",
+			UserInfoURL:  "https://api.github.com/user",
+			ClientID:     os.Getenv("GITHUB_CLIENT_ID"),
+			ClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+			RedirectURL:  os.Getenv("OAUTH_REDIRECT_BASE_URL") + "/api/oauth/github/callback",
+			Scopes:       []string{"read:user", "user:email"},
+		},
+	}
+}
+
+// Get looks up a registered provider by name.
+func Get(name string) (Provider, bool) {
+	p, ok := Providers[name]
+	return p, ok
+}
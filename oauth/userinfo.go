@@ -0,0 +1,110 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// UserInfo is the provider-agnostic identity returned by FetchUserInfo.
+type UserInfo struct {
+	ProviderUserID string
+	Email          string
+}
+
+// FetchUserInfo resolves the caller's identity for the given provider. For
+// Google this verifies the id_token's signature against the provider's JWKS
+// instead of calling the userinfo endpoint; for GitHub it calls the REST API.
+func FetchUserInfo(ctx context.Context, p Provider, token *TokenResponse) (*UserInfo, error) {
+	switch p.Name {
+	case "google":
+		return googleUserInfo(p, token)
+	case "github":
+		return githubUserInfo(ctx, token)
+	default:
+		return nil, fmt.Errorf("oauth: no userinfo handling for provider %q", p.Name)
+	}
+}
+
+func googleUserInfo(p Provider, token *TokenResponse) (*UserInfo, error) {
+	if token.IDToken == "" {
+		return nil, errors.New("oauth: google response had no id_token")
+	}
+
+	claims, err := verifyIDToken(p, token.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	email, _ := claims["email"].(string)
+	if sub == "" || email == "" {
+		return nil, errors.New("oauth: google id_token missing sub/email")
+	}
+
+	return &UserInfo{ProviderUserID: sub, Email: email}, nil
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Email string `json:"email"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+func githubUserInfo(ctx context.Context, token *TokenResponse) (*UserInfo, error) {
+	var user githubUser
+	if err := githubGet(ctx, token.AccessToken, "https://api.github.com/user", &user); err != nil {
+		return nil, err
+	}
+
+	email := user.Email
+	if email == "" {
+		var emails []githubEmail
+		if err := githubGet(ctx, token.AccessToken, "https://api.github.com/user/emails", &emails); err != nil {
+			return nil, err
+		}
+		for _, e := range emails {
+			if e.Primary && e.Verified {
+				email = e.Email
+				break
+			}
+		}
+	}
+	if email == "" {
+		return nil, errors.New("oauth: github account has no verified primary email")
+	}
+
+	return &UserInfo{ProviderUserID: fmt.Sprintf("%d", user.ID), Email: email}, nil
+}
+
+func githubGet(ctx context.Context, accessToken, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("oauth: github %s returned %d: %s", url, resp.StatusCode, body)
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,43 @@
+package otp
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Config controls how long a code stays valid and how many guesses it
+// tolerates before the key is locked out.
+type Config struct {
+	Expiry      time.Duration
+	MaxAttempts int
+}
+
+// DefaultConfig returns the limits used when nothing is configured: codes
+// expire after 5 minutes and tolerate 5 guesses.
+func DefaultConfig() Config {
+	return Config{
+		Expiry:      5 * time.Minute,
+		MaxAttempts: 5,
+	}
+}
+
+// ConfigFromEnv reads OTP_EXPIRY_SECONDS and OTP_MAX_ATTEMPTS, falling back
+// to DefaultConfig for anything unset or invalid.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+
+	if raw := os.Getenv("OTP_EXPIRY_SECONDS"); raw != "" {
+		if seconds, err := strconv.Atoi(raw); err == nil {
+			cfg.Expiry = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if raw := os.Getenv("OTP_MAX_ATTEMPTS"); raw != "" {
+		if attempts, err := strconv.Atoi(raw); err == nil {
+			cfg.MaxAttempts = attempts
+		}
+	}
+
+	return cfg
+}
@@ -0,0 +1,16 @@
+package otp
+
+import (
+	"context"
+	"log"
+)
+
+// NoopSender logs the code instead of delivering it anywhere. Selecting it
+// (OTP_SENDER_BACKEND=noop) lets tests and local development run without
+// real SMTP or Twilio credentials.
+type NoopSender struct{}
+
+func (NoopSender) Send(_ context.Context, to, subject, body string) error {
+	log.Printf("otp: would send %q to %s: %s", subject, to, body)
+	return nil
+}
@@ -0,0 +1,64 @@
+package otp
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// attemptsTTL bounds how long an attempts counter outlives its code, so a
+// stale counter can't lock out a key forever.
+const attemptsTTL = 24 * time.Hour
+
+// RedisStore is a Store backed by Redis, suitable for multi-instance
+// deployments where MemoryStore would leave each instance with its own
+// view of outstanding codes.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore wraps an existing Redis client.
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Put(ctx context.Context, key, code string, ttl time.Duration) error {
+	ok, err := s.client.SetNX(ctx, codeKey(key), code, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAlreadyPending
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) (string, error) {
+	code, err := s.client.Get(ctx, codeKey(key)).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, codeKey(key), attemptsKey(key)).Err()
+}
+
+func (s *RedisStore) IncrAttempts(ctx context.Context, key string) (int, error) {
+	n, err := s.client.Incr(ctx, attemptsKey(key)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		s.client.Expire(ctx, attemptsKey(key), attemptsTTL)
+	}
+	return int(n), nil
+}
+
+func codeKey(key string) string     { return "otp:code:" + key }
+func attemptsKey(key string) string { return "otp:attempts:" + key }
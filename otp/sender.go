@@ -0,0 +1,10 @@
+package otp
+
+import "context"
+
+// Sender delivers a short message to a user through some channel. It was
+// originally OTP-only; RegisterUser and the password-reset flow now share
+// it for any one-off notification that needs a subject and body.
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
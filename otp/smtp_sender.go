@@ -0,0 +1,25 @@
+package otp
+
+import (
+	"context"
+	"net/smtp"
+)
+
+// SMTPSender delivers codes by email, the same way RegisterUser always has.
+type SMTPSender struct {
+	Host     string
+	Addr     string // host:port, e.g. "smtp.gmail.com:587"
+	Username string
+	Password string
+}
+
+// NewSMTPSender builds a sender for the given SMTP host/port and credentials.
+func NewSMTPSender(host, addr, username, password string) *SMTPSender {
+	return &SMTPSender{Host: host, Addr: addr, Username: username, Password: password}
+}
+
+func (s *SMTPSender) Send(_ context.Context, to, subject, body string) error {
+	auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	message := []byte("To : " + to + "\r\nSubject : " + subject + "\r\n  \r\n" + body + "\n")
+	return smtp.SendMail(s.Addr, auth, s.Username, []string{to}, message)
+}
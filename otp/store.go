@@ -0,0 +1,177 @@
+// Package otp provides pluggable storage and delivery for one-time
+// passwords, replacing the process-local map that used to live in
+// controllers.
+package otp
+
+import (
+	"container/heap"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by Get (and by MemoryStore.IncrAttempts) when a
+// code does not exist, either because it was never issued or because it has
+// expired.
+var ErrNotFound = errors.New("otp: code not found or expired")
+
+// ErrAlreadyPending is returned by Put when a code is already outstanding
+// for the given key.
+var ErrAlreadyPending = errors.New("otp: a code is already pending for this key")
+
+// Store persists one-time passwords with a TTL and tracks verification
+// attempts per key, independent of any particular backend.
+type Store interface {
+	Put(ctx context.Context, key, code string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, key string) error
+	IncrAttempts(ctx context.Context, key string) (int, error)
+}
+
+// GenerateCode returns a cryptographically random 5-digit numeric code.
+func GenerateCode() (string, error) {
+	min := int64(10000)
+	max := int64(99999)
+
+	n, err := rand.Int(rand.Reader, big.NewInt(max-min))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%05d", n.Int64()+min), nil
+}
+
+// memoryEntry is one pending code tracked by MemoryStore.
+type memoryEntry struct {
+	key       string
+	code      string
+	attempts  int
+	expiresAt time.Time
+	index     int
+}
+
+// expiryHeap orders memoryEntry by soonest expiry, for the sweeper.
+type expiryHeap []*memoryEntry
+
+func (h expiryHeap) Len() int            { return len(h) }
+func (h expiryHeap) Less(i, j int) bool  { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i]; h[i].index = i; h[j].index = j }
+func (h *expiryHeap) Push(x interface{}) {
+	e := x.(*memoryEntry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*h = old[:n-1]
+	return e
+}
+
+// MemoryStore is an in-process Store with per-key expiry, swept by a
+// background goroutine so expired codes don't linger in memory. It is
+// only suitable for a single instance; see RedisStore for multi-instance
+// deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	heap    expiryHeap
+	now     func() time.Time
+	sweep   time.Duration
+}
+
+// NewMemoryStore returns a MemoryStore that sweeps expired codes every
+// sweepInterval.
+func NewMemoryStore(sweepInterval time.Duration) *MemoryStore {
+	return newMemoryStore(sweepInterval, time.Now)
+}
+
+// newMemoryStore lets tests substitute a fake clock.
+func newMemoryStore(sweepInterval time.Duration, now func() time.Time) *MemoryStore {
+	s := &MemoryStore{
+		entries: make(map[string]*memoryEntry),
+		now:     now,
+		sweep:   sweepInterval,
+	}
+	go s.sweepLoop()
+	return s
+}
+
+func (s *MemoryStore) Put(_ context.Context, key, code string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.entries[key]; ok && existing.expiresAt.After(s.now()) {
+		return ErrAlreadyPending
+	} else if ok {
+		heap.Remove(&s.heap, existing.index)
+	}
+
+	entry := &memoryEntry{key: key, code: code, expiresAt: s.now().Add(ttl)}
+	s.entries[key] = entry
+	heap.Push(&s.heap, entry)
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expiresAt.Before(s.now()) {
+		return "", ErrNotFound
+	}
+	return entry.code, nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		heap.Remove(&s.heap, entry.index)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) IncrAttempts(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expiresAt.Before(s.now()) {
+		return 0, ErrNotFound
+	}
+	entry.attempts++
+	return entry.attempts, nil
+}
+
+// sweepLoop periodically evicts expired entries so a forgotten OTP doesn't
+// sit in memory forever.
+func (s *MemoryStore) sweepLoop() {
+	ticker := time.NewTicker(s.sweep)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.evictExpired()
+	}
+}
+
+func (s *MemoryStore) evictExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for s.heap.Len() > 0 && s.heap[0].expiresAt.Before(now) {
+		entry := heap.Pop(&s.heap).(*memoryEntry)
+		delete(s.entries, entry.key)
+	}
+}
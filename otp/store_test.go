@@ -0,0 +1,104 @@
+package otp
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock lets tests move time forward deterministically instead of
+// sleeping.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestStore(clock *fakeClock) *MemoryStore {
+	return newMemoryStore(time.Hour, clock.Now)
+}
+
+func TestMemoryStorePutGet(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	store := newTestStore(clock)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "a@example.com", "12345", time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	code, err := store.Get(ctx, "a@example.com")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if code != "12345" {
+		t.Fatalf("Get = %q, want 12345", code)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	store := newTestStore(clock)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "a@example.com", "12345", time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	clock.Advance(2 * time.Minute)
+
+	if _, err := store.Get(ctx, "a@example.com"); err != ErrNotFound {
+		t.Fatalf("Get after expiry = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStoreAlreadyPending(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	store := newTestStore(clock)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "a@example.com", "12345", time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if err := store.Put(ctx, "a@example.com", "54321", time.Minute); err != ErrAlreadyPending {
+		t.Fatalf("Put while pending = %v, want ErrAlreadyPending", err)
+	}
+}
+
+func TestMemoryStoreIncrAttempts(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	store := newTestStore(clock)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "a@example.com", "12345", time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		attempts, err := store.IncrAttempts(ctx, "a@example.com")
+		if err != nil {
+			t.Fatalf("IncrAttempts: %v", err)
+		}
+		if attempts != i {
+			t.Fatalf("IncrAttempts = %d, want %d", attempts, i)
+		}
+	}
+}
+
+func TestMemoryStoreDelete(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	store := newTestStore(clock)
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "a@example.com", "12345", time.Minute); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if err := store.Delete(ctx, "a@example.com"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, "a@example.com"); err != ErrNotFound {
+		t.Fatalf("Get after delete = %v, want ErrNotFound", err)
+	}
+}
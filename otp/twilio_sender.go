@@ -0,0 +1,58 @@
+package otp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const twilioAPIBase = "https://api.twilio.com/2010-04-01/Accounts"
+
+// TwilioSender delivers codes as an SMS via the Twilio Messages API.
+type TwilioSender struct {
+	AccountSID string
+	AuthToken  string
+	FromNumber string
+	httpClient *http.Client
+}
+
+// NewTwilioSender builds a sender for the given Twilio account.
+func NewTwilioSender(accountSID, authToken, fromNumber string) *TwilioSender {
+	return &TwilioSender{
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		FromNumber: fromNumber,
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (s *TwilioSender) Send(ctx context.Context, to, _, body string) error {
+	endpoint := fmt.Sprintf("%s/%s/Messages.json", twilioAPIBase, s.AccountSID)
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", s.FromNumber)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Body = io.NopCloser(strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("twilio: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
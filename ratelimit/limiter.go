@@ -0,0 +1,87 @@
+// Package ratelimit implements a simple fixed-window token-bucket limiter,
+// for endpoints (like password reset requests) that need a cheap per-key
+// cap without pulling in a dedicated rate-limiting service.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	count   int
+	resetAt time.Time
+}
+
+// Limiter caps how many times Allow can return true for a given key within
+// a fixed window of the configured size, which starts at a key's first call. A background goroutine sweeps
+// expired buckets so one distinct caller per key doesn't grow the map
+// forever.
+type Limiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+}
+
+// New returns a Limiter allowing up to limit calls per key every window,
+// sweeping expired buckets once per window.
+func New(limit int, window time.Duration) *Limiter {
+	return newLimiter(limit, window, time.Now)
+}
+
+// newLimiter lets tests substitute a fake clock.
+func newLimiter(limit int, window time.Duration, now func() time.Time) *Limiter {
+	l := &Limiter{
+		buckets: make(map[string]*bucket),
+		limit:   limit,
+		window:  window,
+		now:     now,
+	}
+	go l.sweepLoop()
+	return l
+}
+
+// Allow reports whether key is still under its limit for the current
+// window, counting this call if so.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok || now.After(b.resetAt) {
+		b = &bucket{resetAt: now.Add(l.window)}
+		l.buckets[key] = b
+	}
+
+	if b.count >= l.limit {
+		return false
+	}
+	b.count++
+	return true
+}
+
+// sweepLoop periodically evicts buckets whose window has already elapsed,
+// so a key that stops calling Allow doesn't linger in the map forever.
+func (l *Limiter) sweepLoop() {
+	ticker := time.NewTicker(l.window)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.evictExpired()
+	}
+}
+
+func (l *Limiter) evictExpired() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		if now.After(b.resetAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package ratelimit
+
+import (
+	"auth/apierr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware rejects requests once keyFunc(c) has hit limiter's cap for the
+// current window.
+func Middleware(limiter *Limiter, keyFunc func(*gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limiter.Allow(keyFunc(c)) {
+			c.Error(apierr.TooManyRequests("too many requests, please try again later"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,186 @@
+// Package totp implements RFC 6238 TOTP generation and verification, plus
+// AES-GCM encryption of the secret at rest and bcrypt-hashed recovery codes.
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+
+	qrcode "github.com/skip2/go-qrcode"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// step is the RFC 6238 time step in seconds.
+	step = 30
+	// skew is how many steps before/after the current one are still accepted,
+	// to tolerate clock drift between server and authenticator app.
+	skew = 1
+	// digits is the number of digits in a generated code.
+	digits = 6
+)
+
+// GenerateSecret returns a random 20-byte secret, base32 encoded.
+func GenerateSecret() (string, error) {
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw), nil
+}
+
+// URI builds the otpauth:// URI that authenticator apps scan / import.
+func URI(issuer, email, secret string) string {
+	label := url.PathEscape(fmt.Sprintf("%s:%s", issuer, email))
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// QRCodeDataURI renders uri as a PNG QR code and returns it as a data: URI.
+func QRCodeDataURI(uri string) (string, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// counterAt returns the RFC 6238 time step counter for unixTime.
+func counterAt(unixTime int64) uint64 {
+	return uint64(unixTime / step)
+}
+
+// generateAt computes the 6-digit TOTP code for secret at the given counter.
+func generateAt(secret string, counter uint64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return "", err
+	}
+
+	var counterBytes [8]byte
+	binary.BigEndian.PutUint64(counterBytes[:], counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counterBytes[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	truncated := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	code := truncated % 1000000
+	return fmt.Sprintf("%0*d", digits, code), nil
+}
+
+// Validate checks code against secret for the current time, allowing a
+// ±1 step window. It returns the matched counter so the caller can persist
+// it and reject replays of the same or an earlier step.
+func Validate(secret, code string, unixTime int64, lastCounter int64) (matchedCounter int64, ok bool, err error) {
+	current := counterAt(unixTime)
+	for _, delta := range []int{0, -skew, skew} {
+		c := int64(current) + int64(delta)
+		if c < 0 || c <= lastCounter {
+			continue
+		}
+		expected, genErr := generateAt(secret, uint64(c))
+		if genErr != nil {
+			return 0, false, genErr
+		}
+		if expected == code {
+			return c, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+// GenerateRecoveryCodes returns n random recovery codes and their bcrypt hashes.
+func GenerateRecoveryCodes(n int) (plain []string, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		buf := make([]byte, 5)
+		if _, readErr := io.ReadFull(rand.Reader, buf); readErr != nil {
+			return nil, nil, readErr
+		}
+		code := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)
+		hash, hashErr := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if hashErr != nil {
+			return nil, nil, hashErr
+		}
+		plain = append(plain, code)
+		hashes = append(hashes, string(hash))
+	}
+	return plain, hashes, nil
+}
+
+// Encrypt seals secret with AES-GCM using a key derived from TOTP_ENCRYPTION_KEY.
+func Encrypt(secret string) (string, error) {
+	block, err := newCipherBlock()
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt reverses Encrypt.
+func Decrypt(ciphertext string) (string, error) {
+	block, err := newCipherBlock()
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(raw) < nonceSize {
+		return "", errors.New("totp: ciphertext too short")
+	}
+
+	nonce, sealed := raw[:nonceSize], raw[nonceSize:]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// newCipherBlock derives a 32-byte AES key from TOTP_ENCRYPTION_KEY.
+func newCipherBlock() (cipher.Block, error) {
+	key := os.Getenv("TOTP_ENCRYPTION_KEY")
+	if key == "" {
+		return nil, errors.New("totp: TOTP_ENCRYPTION_KEY is not set")
+	}
+	sum := sha256.Sum256([]byte(key))
+	return aes.NewCipher(sum[:])
+}